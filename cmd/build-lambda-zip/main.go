@@ -6,6 +6,7 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"flag"
 	"fmt"
 	"io"
@@ -14,9 +15,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 )
@@ -33,6 +36,11 @@ notes:
 options:
   -o, --output          <output-path>     sets the output file path for the zip. (default: ${handler-exe}.zip)
   -u, --update-function <function-name>   pushes the built zip as a code update to the named function
+  --arch                <amd64|arm64>     sets GOARCH for the build and the provided.al2/al2023 base (default: amd64)
+  --layer                                 produces a Lambda layer zip (handler under bin/) instead of a function zip
+  --publish-layer       <layer-name>      publishes the layer zip as a new version of the named layer (implies --layer)
+  --image                                 builds an OCI container image instead of a zip
+  --repository          <ecr-repository>  ECR repository URI to tag and push the image to (required with --image)
   -h, --help                              prints usage
 `
 
@@ -43,6 +51,16 @@ func main() {
 	var functionName string
 	flag.StringVar(&functionName, "u", "", "")
 	flag.StringVar(&functionName, "update-function", "", "")
+	var arch string
+	flag.StringVar(&arch, "arch", "amd64", "")
+	var asLayer bool
+	flag.BoolVar(&asLayer, "layer", false, "")
+	var publishLayerName string
+	flag.StringVar(&publishLayerName, "publish-layer", "", "")
+	var asImage bool
+	flag.BoolVar(&asImage, "image", false, "")
+	var repository string
+	flag.StringVar(&repository, "repository", "", "")
 	flag.Usage = func() {
 		fmt.Fprint(os.Stderr, usage)
 	}
@@ -50,13 +68,23 @@ func main() {
 	if len(flag.Args()) == 0 {
 		log.Fatal("no input provided")
 	}
+	if arch != "amd64" && arch != "arm64" {
+		log.Fatalf("unsupported --arch %q: must be amd64 or arm64", arch)
+	}
+	if publishLayerName != "" {
+		asLayer = true
+	}
+	if asImage && repository == "" {
+		log.Fatal("--image requires --repository")
+	}
+
 	inputExe := flag.Arg(0)
 	if outputZip == "" {
 		outputZip = fmt.Sprintf("%s.zip", filepath.Base(inputExe))
 	}
 
 	if filepath.Ext(inputExe) == ".go" {
-		builtExePath, err := goBuild(inputExe)
+		builtExePath, err := goBuild(inputExe, arch)
 		if err != nil {
 			log.Fatalf("failed to compile .go file %s: %v", inputExe, err)
 		}
@@ -70,12 +98,30 @@ func main() {
 		inputExe = builtExePath
 	}
 
-	if functionName == "" {
+	switch {
+	case asImage:
+		if err := buildAndPushImage(repository, arch, inputExe, flag.Args()[1:]); err != nil {
+			log.Fatalf("failed to build and push image: %v", err)
+		}
+		log.Printf("pushed image to %s", repository)
+	case asLayer:
+		if err := compressLayerToFile(outputZip, inputExe, flag.Args()[1:]); err != nil {
+			log.Fatalf("failed to compress layer: %v", err)
+		}
+		log.Printf("wrote layer zip %s", outputZip)
+		if publishLayerName != "" {
+			version, err := publishLayerVersion(publishLayerName, outputZip, arch)
+			if err != nil {
+				log.Fatalf("failed to publish layer version: %v", err)
+			}
+			log.Printf("published %s as version %d", publishLayerName, version)
+		}
+	case functionName == "":
 		if err := compressExeAndArgsToFile(outputZip, inputExe, flag.Args()[1:]); err != nil {
 			log.Fatalf("failed to compress file: %v", err)
 		}
 		log.Printf("wrote %s", outputZip)
-	} else {
+	default:
 		if err := updateFunctionCode(functionName, inputExe, flag.Args()[1:]); err != nil {
 			log.Fatalf("failed to update function code: %v", err)
 		}
@@ -83,6 +129,24 @@ func main() {
 	}
 }
 
+func goarchToLambdaArchitecture(arch string) types.Architecture {
+	if arch == "arm64" {
+		return types.ArchitectureArm64
+	}
+	return types.ArchitectureX8664
+}
+
+// providedBaseImageTag returns the provided.al2/provided.al2023 container
+// base image tag appropriate for arch. arm64 functions are only supported
+// on provided.al2023 and newer; amd64 keeps using provided.al2 for the
+// widest compatibility.
+func providedBaseImageTag(arch string) string {
+	if arch == "arm64" {
+		return "public.ecr.aws/lambda/provided:al2023-arm64"
+	}
+	return "public.ecr.aws/lambda/provided:al2"
+}
+
 func writeExe(writer *zip.Writer, pathInZip string, data []byte) error {
 	if pathInZip != "bootstrap" {
 		header := &zip.FileHeader{Name: "bootstrap", Method: zip.Deflate}
@@ -200,13 +264,186 @@ func compressExeAndArgs(zipFile io.Writer, exePath string, args []string) error
 	return err
 }
 
-func goBuild(in string) (string, error) {
+// compressLayerToFile writes a Lambda layer zip to outZipPath. Layer
+// content is extracted to /opt in the execution environment, so the
+// handler and any supplemental files are placed under bin/ rather than at
+// the zip root the way a function deployment package is.
+func compressLayerToFile(outZipPath string, exePath string, args []string) error {
+	zipFile, err := os.Create(outZipPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := zipFile.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close zip file: %v\n", closeErr)
+		}
+	}()
+
+	zipWriter := zip.NewWriter(zipFile)
+	defer zipWriter.Close()
+
+	data, err := ioutil.ReadFile(exePath)
+	if err != nil {
+		return err
+	}
+	exe, err := zipWriter.CreateHeader(&zip.FileHeader{
+		CreatorVersion: 3 << 8,
+		ExternalAttrs:  0777 << 16,
+		Name:           filepath.Join("bin", filepath.Base(exePath)),
+		Method:         zip.Deflate,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := exe.Write(data); err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		writer, err := zipWriter.Create(filepath.Join("bin", arg))
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(arg)
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishLayerVersion publishes the zip at zipPath as a new version of the
+// named layer, restricted to the given architecture, and returns the new
+// version number.
+func publishLayerVersion(layerName, zipPath, arch string) (int64, error) {
+	data, err := ioutil.ReadFile(zipPath)
+	if err != nil {
+		return 0, err
+	}
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	svc := lambda.NewFromConfig(cfg)
+	resp, err := svc.PublishLayerVersion(context.Background(), &lambda.PublishLayerVersionInput{
+		LayerName: &layerName,
+		Content:   &types.LayerVersionContentInput{ZipFile: data},
+		CompatibleArchitectures: []types.Architecture{
+			goarchToLambdaArchitecture(arch),
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Version, nil
+}
+
+// buildAndPushImage builds an OCI image for a container-image Lambda
+// function from exePath and args, using the provided.al2/al2023 base image
+// appropriate for arch, then tags and pushes it to repository. It shells
+// out to the local "docker" CLI, which must be installed and running, and
+// uses the aws-sdk-go-v2 ECR client to obtain a short-lived login token.
+func buildAndPushImage(repository, arch, exePath string, args []string) error {
+	buildDir, err := ioutil.TempDir("", "build-lambda-zip-image-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(buildDir)
+
+	if err := copyFile(exePath, filepath.Join(buildDir, "bootstrap")); err != nil {
+		return err
+	}
+	if err := os.Chmod(filepath.Join(buildDir, "bootstrap"), 0755); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		if err := copyFile(arg, filepath.Join(buildDir, filepath.Base(arg))); err != nil {
+			return err
+		}
+	}
+
+	dockerfile := fmt.Sprintf("FROM %s\nCOPY . ${LAMBDA_TASK_ROOT}\nCMD [ \"bootstrap\" ]\n", providedBaseImageTag(arch))
+	if err := ioutil.WriteFile(filepath.Join(buildDir, "Dockerfile"), []byte(dockerfile), 0644); err != nil {
+		return err
+	}
+	// Keep the Dockerfile (and this file itself) out of the image: the
+	// Dockerfile's COPY . would otherwise ship them to ${LAMBDA_TASK_ROOT}
+	// alongside the handler.
+	dockerignore := "Dockerfile\n.dockerignore\n"
+	if err := ioutil.WriteFile(filepath.Join(buildDir, ".dockerignore"), []byte(dockerignore), 0644); err != nil {
+		return err
+	}
+
+	tag := repository + ":latest"
+	if err := runCommand("docker", "build", "--platform", "linux/"+arch, "-t", tag, buildDir); err != nil {
+		return fmt.Errorf("docker build failed: %w", err)
+	}
+
+	if err := ecrLogin(repository); err != nil {
+		return fmt.Errorf("ecr login failed: %w", err)
+	}
+
+	if err := runCommand("docker", "push", tag); err != nil {
+		return fmt.Errorf("docker push failed: %w", err)
+	}
+	return nil
+}
+
+// ecrLogin fetches a short-lived authorization token from ECR and pipes it
+// into "docker login", the way `aws ecr get-login-password` does.
+func ecrLogin(repository string) error {
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return err
+	}
+	svc := ecr.NewFromConfig(cfg)
+	authResp, err := svc.GetAuthorizationToken(context.Background(), &ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return err
+	}
+	if len(authResp.AuthorizationData) == 0 {
+		return fmt.Errorf("ecr returned no authorization data")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(deref(authResp.AuthorizationData[0].AuthorizationToken))
+	if err != nil {
+		return err
+	}
+	password := strings.TrimPrefix(string(decoded), "AWS:")
+
+	registry := strings.SplitN(repository, "/", 2)[0]
+	cmd := exec.Command("docker", "login", "--username", "AWS", "--password-stdin", registry)
+	cmd.Stdin = strings.NewReader(password)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func copyFile(src, dst string) error {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, data, 0644)
+}
+
+func runCommand(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func goBuild(in string, arch string) (string, error) {
 	out := fmt.Sprintf("%s.exe", filepath.Base(in))
 	cmd := exec.Command("go", "build", "-o", out, in)
 	cmd.Env = append(
 		[]string{
 			"GOOS=linux",
-			"GOARCH=amd64",
+			"GOARCH=" + arch,
 		},
 		os.Environ()...,
 	)
@@ -0,0 +1,222 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved
+
+// Command prune-lambda-versions deletes old numbered versions of a Lambda
+// function, keeping $LATEST and the N most recent numbered versions. Every
+// UpdateFunctionCode call (including the ones build-lambda-zip performs)
+// leaves behind an immutable version that counts against the per-region
+// code-storage quota; this tool cleans those up.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+const usage = `prune-lambda-versions - Deletes old numbered versions of a Lambda function.
+usage:
+  prune-lambda-versions [options] function-name
+
+options:
+  --retain        <n>      number of most recent numbered versions to keep, in addition to $LATEST (default: 3)
+  --aliases-safe            never delete a version that is referenced by an alias
+  --dry-run                 print what would be deleted without deleting anything
+  --concurrency   <n>      number of versions to delete in parallel (default: 4)
+  -h, --help                prints usage
+`
+
+func main() {
+	var retain int
+	flag.IntVar(&retain, "retain", 3, "")
+	var aliasesSafe bool
+	flag.BoolVar(&aliasesSafe, "aliases-safe", false, "")
+	var dryRun bool
+	flag.BoolVar(&dryRun, "dry-run", false, "")
+	var concurrency int
+	flag.IntVar(&concurrency, "concurrency", 4, "")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+	}
+	flag.Parse()
+
+	if len(flag.Args()) != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	functionName := flag.Arg(0)
+	if retain < 0 {
+		log.Fatal("--retain must not be negative")
+	}
+	if concurrency < 1 {
+		log.Fatal("--concurrency must be at least 1")
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		log.Fatalf("failed to load AWS config: %v", err)
+	}
+	svc := lambda.NewFromConfig(cfg)
+
+	if err := run(context.Background(), svc, functionName, retain, aliasesSafe, dryRun, concurrency); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, svc *lambda.Client, functionName string, retain int, aliasesSafe bool, dryRun bool, concurrency int) error {
+	versions, err := listNumberedVersions(ctx, svc, functionName)
+	if err != nil {
+		return fmt.Errorf("failed to list versions: %w", err)
+	}
+
+	keep := make(map[string]bool, retain)
+	for _, v := range versions[:min(retain, len(versions))] {
+		keep[v] = true
+	}
+
+	if aliasesSafe {
+		aliased, err := listAliasedVersions(ctx, svc, functionName)
+		if err != nil {
+			return fmt.Errorf("failed to list aliases: %w", err)
+		}
+		for v := range aliased {
+			keep[v] = true
+		}
+	}
+
+	var toDelete []string
+	for _, v := range versions {
+		if !keep[v] {
+			toDelete = append(toDelete, v)
+		}
+	}
+
+	if len(toDelete) == 0 {
+		log.Printf("nothing to prune for %s (retaining %d of %d versions)", functionName, len(keep), len(versions))
+		return nil
+	}
+
+	if dryRun {
+		for _, v := range toDelete {
+			log.Printf("[dry-run] would delete %s:%s", functionName, v)
+		}
+		return nil
+	}
+
+	return deleteVersions(ctx, svc, functionName, toDelete, concurrency)
+}
+
+// listNumberedVersions returns every numbered version of functionName (that
+// is, every version except $LATEST), ordered from newest to oldest.
+func listNumberedVersions(ctx context.Context, svc *lambda.Client, functionName string) ([]string, error) {
+	var versions []string
+	var marker *string
+	for {
+		resp, err := svc.ListVersionsByFunction(ctx, &lambda.ListVersionsByFunctionInput{
+			FunctionName: &functionName,
+			Marker:       marker,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range resp.Versions {
+			version := deref(v.Version)
+			if version == "" || version == "$LATEST" {
+				continue
+			}
+			versions = append(versions, version)
+		}
+		if resp.NextMarker == nil {
+			break
+		}
+		marker = resp.NextMarker
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		vi, _ := strconv.ParseInt(versions[i], 10, 64)
+		vj, _ := strconv.ParseInt(versions[j], 10, 64)
+		return vi > vj
+	})
+	return versions, nil
+}
+
+// listAliasedVersions returns the set of versions referenced by any alias
+// of functionName.
+func listAliasedVersions(ctx context.Context, svc *lambda.Client, functionName string) (map[string]bool, error) {
+	aliased := make(map[string]bool)
+	var marker *string
+	for {
+		resp, err := svc.ListAliases(ctx, &lambda.ListAliasesInput{
+			FunctionName: &functionName,
+			Marker:       marker,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range resp.Aliases {
+			aliased[deref(a.FunctionVersion)] = true
+		}
+		if resp.NextMarker == nil {
+			break
+		}
+		marker = resp.NextMarker
+	}
+	return aliased, nil
+}
+
+// deleteVersions deletes each of versions with up to concurrency deletions
+// in flight at once, returning the first error encountered (if any), after
+// all deletions have been attempted.
+func deleteVersions(ctx context.Context, svc *lambda.Client, functionName string, versions []string, concurrency int) error {
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+
+	for _, version := range versions {
+		version := version
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			_, err := svc.DeleteFunction(ctx, &lambda.DeleteFunctionInput{
+				FunctionName: &functionName,
+				Qualifier:    &version,
+			})
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to delete %s:%s: %w", functionName, version, err)
+				}
+				mu.Unlock()
+				return
+			}
+			log.Printf("deleted %s:%s", functionName, version)
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
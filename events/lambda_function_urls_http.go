@@ -0,0 +1,125 @@
+// Copyright 2023 Amazon.com, Inc. or its affiliates. All Rights Reserved
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+)
+
+// NewHTTPRequestFromFunctionURL reconstructs a standard *http.Request from a
+// LambdaFunctionURLRequest, so that handlers built on net/http (and the
+// middleware/routers that expect it) can be reused behind a Lambda Function
+// URL. The returned request's context is ctx.
+func NewHTTPRequestFromFunctionURL(ctx context.Context, functionURLReq *LambdaFunctionURLRequest) (*http.Request, error) {
+	var body io.Reader
+	if functionURLReq.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(functionURLReq.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to base64 decode body: %w", err)
+		}
+		body = bytes.NewReader(decoded)
+	} else if functionURLReq.Body != "" {
+		body = bytes.NewReader([]byte(functionURLReq.Body))
+	}
+
+	// RawPath is already percent-encoded (e.g. "/foo%2Fbar"), so it must be
+	// parsed rather than assigned straight into url.URL{Path: ...}: that
+	// would leave url.URL.RawPath unset, and url.String() would then
+	// re-escape the already-encoded path, double-encoding every "%".
+	requestURL, err := url.Parse(functionURLReq.RawPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse raw path %q: %w", functionURLReq.RawPath, err)
+	}
+	requestURL.RawQuery = functionURLReq.RawQueryString
+
+	httpReq, err := http.NewRequestWithContext(ctx, functionURLReq.RequestContext.HTTP.Method, requestURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range http.Header(functionURLReq.Headers) {
+		for _, v := range values {
+			httpReq.Header.Add(name, v)
+		}
+	}
+
+	for _, cookie := range functionURLReq.Cookies {
+		httpReq.Header.Add("Cookie", cookie)
+	}
+
+	httpReq.RemoteAddr = functionURLReq.RequestContext.HTTP.SourceIP
+	if host := httpReq.Header.Get("Host"); host != "" {
+		httpReq.Host = host
+	}
+
+	return httpReq, nil
+}
+
+// ServeFunctionURL adapts an http.Handler into a Lambda handler function
+// that can be passed directly to lambda.Start, so that handlers and
+// middleware written against net/http can run unmodified behind a Lambda
+// Function URL.
+func ServeFunctionURL(h http.Handler) func(context.Context, LambdaFunctionURLRequest) (LambdaFunctionURLResponse, error) {
+	return func(ctx context.Context, functionURLReq LambdaFunctionURLRequest) (LambdaFunctionURLResponse, error) {
+		httpReq, err := NewHTTPRequestFromFunctionURL(ctx, &functionURLReq)
+		if err != nil {
+			return LambdaFunctionURLResponse{}, err
+		}
+
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, httpReq)
+		result := recorder.Result()
+		defer result.Body.Close()
+
+		bodyBytes, err := io.ReadAll(result.Body)
+		if err != nil {
+			return LambdaFunctionURLResponse{}, err
+		}
+
+		headers := make(http.Header, len(result.Header))
+		var cookies []string
+		for name, values := range result.Header {
+			if name == "Set-Cookie" {
+				cookies = append(cookies, values...)
+				continue
+			}
+			headers[name] = values
+		}
+
+		isBase64Encoded := !isTextContentType(headers.Get("Content-Type"))
+
+		resp := LambdaFunctionURLResponse{
+			StatusCode: result.StatusCode,
+			Headers:    functionURLHeaders(headers),
+			Cookies:    cookies,
+		}
+		if isBase64Encoded {
+			resp.Body = base64.StdEncoding.EncodeToString(bodyBytes)
+			resp.IsBase64Encoded = true
+		} else {
+			resp.Body = string(bodyBytes)
+		}
+		return resp, nil
+	}
+}
+
+// isTextContentType reports whether contentType is text-ish enough to be
+// sent verbatim instead of base64-encoded.
+func isTextContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	for _, prefix := range []string{"text/", "application/json", "application/xml", "application/javascript", "application/x-www-form-urlencoded"} {
+		if len(contentType) >= len(prefix) && contentType[:len(prefix)] == prefix {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+// Copyright 2023 Amazon.com, Inc. or its affiliates. All Rights Reserved
+
+package events
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewHTTPRequestFromFunctionURL(t *testing.T) {
+	req := &LambdaFunctionURLRequest{
+		RawPath:        "/foo%2Fbar",
+		RawQueryString: "a=b",
+		Headers:        functionURLHeaders{"Content-Type": {"text/plain"}, "Host": {"example.com"}},
+		Cookies:        []string{"session=abc", "theme=dark"},
+		Body:           "hello",
+	}
+	req.RequestContext.HTTP.Method = http.MethodPost
+	req.RequestContext.HTTP.SourceIP = "203.0.113.1"
+
+	httpReq, err := NewHTTPRequestFromFunctionURL(context.Background(), req)
+	if err != nil {
+		t.Fatalf("NewHTTPRequestFromFunctionURL failed: %v", err)
+	}
+
+	// A percent-encoded path segment (the encoded slash in "%2F") must
+	// survive round-tripping through url.URL without being double-encoded:
+	// URL.Path holds the decoded path, and re-stringifying the URL must
+	// reproduce the original escaped form, not "%252F".
+	if got, want := httpReq.URL.Path, "/foo/bar"; got != want {
+		t.Errorf("unexpected decoded path: got %q, want %q", got, want)
+	}
+	if got, want := httpReq.URL.EscapedPath(), "/foo%2Fbar"; got != want {
+		t.Errorf("unexpected re-escaped path: got %q, want %q", got, want)
+	}
+	if got, want := httpReq.URL.RawQuery, "a=b"; got != want {
+		t.Errorf("unexpected raw query: got %q, want %q", got, want)
+	}
+
+	if got, want := httpReq.Method, http.MethodPost; got != want {
+		t.Errorf("unexpected method: got %q, want %q", got, want)
+	}
+	if got, want := httpReq.Host, "example.com"; got != want {
+		t.Errorf("unexpected host: got %q, want %q", got, want)
+	}
+	if got, want := httpReq.RemoteAddr, "203.0.113.1"; got != want {
+		t.Errorf("unexpected remote addr: got %q, want %q", got, want)
+	}
+	if got, want := httpReq.Header.Get("Content-Type"), "text/plain"; got != want {
+		t.Errorf("unexpected Content-Type header: got %q, want %q", got, want)
+	}
+	if got := httpReq.Header.Values("Cookie"); len(got) != 2 || got[0] != "session=abc" || got[1] != "theme=dark" {
+		t.Errorf("unexpected cookie headers: %v", got)
+	}
+
+	bodyBytes, err := io.ReadAll(httpReq.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if got, want := string(bodyBytes), "hello"; got != want {
+		t.Errorf("unexpected body: got %q, want %q", got, want)
+	}
+}
+
+func TestNewHTTPRequestFromFunctionURLBase64Body(t *testing.T) {
+	req := &LambdaFunctionURLRequest{
+		RawPath:         "/",
+		Body:            base64.StdEncoding.EncodeToString([]byte("binary")),
+		IsBase64Encoded: true,
+	}
+	req.RequestContext.HTTP.Method = http.MethodPut
+
+	httpReq, err := NewHTTPRequestFromFunctionURL(context.Background(), req)
+	if err != nil {
+		t.Fatalf("NewHTTPRequestFromFunctionURL failed: %v", err)
+	}
+
+	bodyBytes, err := io.ReadAll(httpReq.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if got, want := string(bodyBytes), "binary"; got != want {
+		t.Errorf("unexpected decoded body: got %q, want %q", got, want)
+	}
+}
+
+func TestServeFunctionURL(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/foo/bar" {
+			t.Errorf("unexpected path seen by handler: %q", r.URL.Path)
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	req := LambdaFunctionURLRequest{RawPath: "/foo%2Fbar"}
+	req.RequestContext.HTTP.Method = http.MethodGet
+
+	resp, err := ServeFunctionURL(handler)(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ServeFunctionURL handler failed: %v", err)
+	}
+
+	if got, want := resp.StatusCode, http.StatusCreated; got != want {
+		t.Errorf("unexpected status code: got %d, want %d", got, want)
+	}
+	if got, want := resp.Body, `{"ok":true}`; got != want {
+		t.Errorf("unexpected body: got %q, want %q", got, want)
+	}
+	if resp.IsBase64Encoded {
+		t.Error("expected a JSON body to be sent as text, not base64")
+	}
+	if got, want := len(resp.Cookies), 1; got != want {
+		t.Fatalf("unexpected cookie count: got %d, want %d", got, want)
+	}
+	if got, want := resp.Cookies[0], "session=abc"; got != want {
+		t.Errorf("unexpected cookie: got %q, want %q", got, want)
+	}
+}
+
+func TestServeFunctionURLBase64EncodesBinaryBody(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte{0x00, 0x01, 0x02})
+	})
+
+	req := LambdaFunctionURLRequest{RawPath: "/"}
+	req.RequestContext.HTTP.Method = http.MethodGet
+
+	resp, err := ServeFunctionURL(handler)(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ServeFunctionURL handler failed: %v", err)
+	}
+
+	if !resp.IsBase64Encoded {
+		t.Fatal("expected a binary content type to be base64-encoded")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if got, want := decoded, []byte{0x00, 0x01, 0x02}; string(got) != string(want) {
+		t.Errorf("unexpected decoded body: got %v, want %v", got, want)
+	}
+}
+
+// TestNewHTTPRequestFromFunctionURLOverHTTP sends the *http.Request that
+// NewHTTPRequestFromFunctionURL reconstructs from a LambdaFunctionURLRequest
+// to a real httptest.Server, and checks that the percent-encoded path
+// reaches the server decoded exactly once.
+func TestNewHTTPRequestFromFunctionURLOverHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	req := LambdaFunctionURLRequest{RawPath: "/foo%2Fbar", RawQueryString: "a=b"}
+	req.RequestContext.HTTP.Method = http.MethodGet
+
+	httpReq, err := NewHTTPRequestFromFunctionURL(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("NewHTTPRequestFromFunctionURL failed: %v", err)
+	}
+	httpReq.URL.Scheme = "http"
+	httpReq.URL.Host = server.Listener.Addr().String()
+	httpReq.RequestURI = ""
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read response body: %v", err)
+	}
+	if got, want := string(body), "/foo/bar"; got != want {
+		t.Errorf("unexpected path seen by server: got %q, want %q", got, want)
+	}
+}
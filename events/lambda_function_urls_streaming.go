@@ -0,0 +1,34 @@
+// Copyright 2023 Amazon.com, Inc. or its affiliates. All Rights Reserved
+
+package events
+
+import "net/http"
+
+// LambdaFunctionURLStreamingResponseContentType is the content type that the
+// Lambda runtime requires a streamed Function URL response to report, so
+// that it is recognized as a framed RESPONSE_STREAM payload rather than a
+// buffered LambdaFunctionURLResponse.
+const LambdaFunctionURLStreamingResponseContentType = "application/vnd.awslambda.http-integration-response"
+
+// LambdaFunctionURLStreamingResponse is the JSON prelude that precedes a
+// streamed Lambda Function URL response body. Unlike LambdaFunctionURLResponse,
+// the body is not carried on this struct: it is written separately, after the
+// prelude and an 8 null-byte delimiter, directly to the runtime API as it
+// becomes available.
+type LambdaFunctionURLStreamingResponse struct {
+	StatusCode int                `json:"statusCode"`
+	Headers    functionURLHeaders `json:"headers,omitempty"`
+	Cookies    []string           `json:"cookies,omitempty"`
+}
+
+// NewLambdaFunctionURLStreamingResponse builds the JSON prelude for a
+// streamed Function URL response, converting headers from the multi-valued
+// net/http representation into the comma-joined form the Lambda runtime
+// expects.
+func NewLambdaFunctionURLStreamingResponse(statusCode int, headers http.Header, cookies []string) LambdaFunctionURLStreamingResponse {
+	return LambdaFunctionURLStreamingResponse{
+		StatusCode: statusCode,
+		Headers:    functionURLHeaders(headers),
+		Cookies:    cookies,
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved
+
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda/messages"
+)
+
+const (
+	headerAwsRequestID = "Lambda-Runtime-Aws-Request-Id"
+	headerDeadlineMs   = "Lambda-Runtime-Deadline-Ms"
+	headerErrorType    = "Lambda-Runtime-Function-Error-Type"
+)
+
+// Start runs the Lambda runtime invocation loop for handlerFunc until the
+// process is shut down. handlerFunc is adapted into a Handler with
+// NewHandler; see its documentation for supported shapes.
+func Start(handlerFunc interface{}) {
+	StartWithOptions(handlerFunc)
+}
+
+// StartWithContext is like Start, but invocations are made with ctx as
+// their base context. It is equivalent to StartWithOptions(handlerFunc,
+// WithContext(ctx)).
+func StartWithContext(ctx context.Context, handlerFunc interface{}) {
+	StartWithOptions(handlerFunc, WithContext(ctx))
+}
+
+// StartHandler is like Start, for callers that have already built a
+// Handler.
+func StartHandler(handler Handler) {
+	StartWithOptions(handler)
+}
+
+// StartWithOptions runs the Lambda runtime invocation loop for handlerFunc,
+// configured by options. It does not return: on a fatal runtime error it
+// logs the failure and exits the process, and on a graceful SIGTERM
+// shutdown it runs any registered shutdown hooks and exits zero.
+func StartWithOptions(handlerFunc interface{}, options ...Option) {
+	handler := NewHandler(handlerFunc)
+	opts := newOptions(options)
+
+	api := os.Getenv("AWS_LAMBDA_RUNTIME_API")
+	if api == "" {
+		fmt.Fprintln(os.Stderr, "AWS_LAMBDA_RUNTIME_API is not set; are you running outside of Lambda?")
+		os.Exit(1)
+	}
+
+	if err := startRuntimeAPILoop(api, handler, opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// shutdownSignal reports the progress of a SIGTERM-triggered shutdown.
+// triggered is closed as soon as SIGTERM is observed and the base context
+// is cancelled, before any shutdown hooks run; done is closed once every
+// hook has returned (or the shutdown window has elapsed). Callers use
+// triggered to tell an intentional shutdown apart from a genuine runtime
+// error, and done to wait for hooks to finish before exiting.
+type shutdownSignal struct {
+	triggered chan struct{}
+	done      chan struct{}
+}
+
+// installShutdownHandler cancels cancel and runs opts.shutdownHooks, bounded
+// by opts.shutdownWindow, the first time the process receives SIGTERM. This
+// is how the runtime signals that an execution environment with registered
+// extensions is being torn down.
+func installShutdownHandler(cancel context.CancelFunc, opts *Options) *shutdownSignal {
+	state := &shutdownSignal{
+		triggered: make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	sigterm := make(chan os.Signal, 1)
+	signal.Notify(sigterm, syscall.SIGTERM)
+
+	go func() {
+		<-sigterm
+		close(state.triggered)
+		cancel()
+
+		shutdownCtx, done := context.WithTimeout(context.Background(), opts.shutdownWindow)
+		defer done()
+
+		hooksDone := make(chan struct{})
+		go func() {
+			for _, hook := range opts.shutdownHooks {
+				hook(shutdownCtx)
+			}
+			close(hooksDone)
+		}()
+		select {
+		case <-hooksDone:
+		case <-shutdownCtx.Done():
+		}
+		close(state.done)
+	}()
+
+	return state
+}
+
+func startRuntimeAPILoop(api string, handler Handler, opts *Options) error {
+	baseCtx, cancel := context.WithCancel(opts.baseContext)
+	defer cancel()
+	shutdown := installShutdownHandler(cancel, opts)
+
+	client := &http.Client{}
+	baseURL := fmt.Sprintf("http://%s/2018-06-01/runtime", api)
+
+	for {
+		if err := handleOneInvocation(baseCtx, client, baseURL, handler); err != nil {
+			return shutdownAwareError(shutdown, err)
+		}
+	}
+}
+
+// shutdownAwareError turns err into nil if it was caused by the SIGTERM
+// shutdown path (rather than a genuine runtime failure), after waiting for
+// the shutdown hooks to finish running.
+func shutdownAwareError(shutdown *shutdownSignal, err error) error {
+	select {
+	case <-shutdown.triggered:
+		<-shutdown.done
+		return nil
+	default:
+		return err
+	}
+}
+
+func handleOneInvocation(baseCtx context.Context, client *http.Client, baseURL string, handler Handler) error {
+	invokeCtx, cancel, requestID, eventPayload, err := nextInvocation(baseCtx, client, baseURL)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	responsePayload, invokeErr, panicResponse := invokeHandler(invokeCtx, handler, eventPayload)
+	if panicResponse != nil {
+		if err := postInvocationErrorResponse(client, baseURL, requestID, panicResponse); err != nil {
+			return err
+		}
+		if panicResponse.ShouldExit {
+			// Mirrors real Lambda behavior: an execution environment that
+			// panicked is not reused. Returning an error here stops the
+			// invoke loop so StartWithOptions exits the process instead of
+			// serving further invocations on top of undefined state.
+			return fmt.Errorf("handler panicked: %s", panicResponse.Message)
+		}
+		return nil
+	}
+	if invokeErr != nil {
+		return postInvocationError(client, baseURL, requestID, invokeErr)
+	}
+	return postInvocationResponse(client, baseURL, requestID, responsePayload)
+}
+
+// invokeHandler calls handler.Invoke, recovering a panic instead of letting
+// it unwind out of the invoke loop and crash the process. A recovered panic
+// is reported via panicResponse rather than invokeErr, since it must be
+// converted with messages.FromRecover instead of messages.FromError.
+func invokeHandler(ctx context.Context, handler Handler, payload []byte) (responsePayload []byte, invokeErr error, panicResponse *messages.InvokeResponse_Error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicResponse = messages.FromRecover(r)
+		}
+	}()
+	responsePayload, invokeErr = handler.Invoke(ctx, payload)
+	return
+}
+
+func nextInvocation(baseCtx context.Context, client *http.Client, baseURL string) (context.Context, context.CancelFunc, string, []byte, error) {
+	req, err := http.NewRequestWithContext(baseCtx, http.MethodGet, baseURL+"/invocation/next", nil)
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, nil, "", nil, fmt.Errorf("runtime next invocation failed with status %s: %s", resp.Status, body)
+	}
+
+	eventPayload, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+
+	requestID := resp.Header.Get(headerAwsRequestID)
+
+	invokeCtx, cancel := baseCtx, func() {}
+	if deadlineMs := resp.Header.Get(headerDeadlineMs); deadlineMs != "" {
+		if ms, err := strconv.ParseInt(deadlineMs, 10, 64); err == nil {
+			invokeCtx, cancel = context.WithDeadline(invokeCtx, time.UnixMilli(ms))
+		}
+	}
+
+	return invokeCtx, cancel, requestID, eventPayload, nil
+}
+
+func postInvocationResponse(client *http.Client, baseURL string, requestID string, payload []byte) error {
+	url := fmt.Sprintf("%s/invocation/%s/response", baseURL, requestID)
+	resp, err := client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("runtime post response failed with status %s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+func postInvocationError(client *http.Client, baseURL string, requestID string, invokeErr error) error {
+	return postInvocationErrorResponse(client, baseURL, requestID, messages.FromError(invokeErr))
+}
+
+func postInvocationErrorResponse(client *http.Client, baseURL string, requestID string, errResponse *messages.InvokeResponse_Error) error {
+	url := fmt.Sprintf("%s/invocation/%s/error", baseURL, requestID)
+
+	payload, err := json.Marshal(errResponse)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(headerErrorType, errResponse.Type)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("runtime post error failed with status %s: %s", resp.Status, body)
+	}
+	return nil
+}
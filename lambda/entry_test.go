@@ -0,0 +1,156 @@
+// Copyright 2023 Amazon.com, Inc. or its affiliates. All Rights Reserved
+
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda/messages"
+)
+
+// TestShutdownHookRunsOnSIGTERM spawns this test binary as a child process
+// (via the TestHelperProcess pattern, see os/exec's own tests), sends it
+// SIGTERM while it is blocked in startRuntimeAPILoop's long poll against an
+// httptest double of the runtime API, and asserts that the child's
+// shutdown hook ran and the loop exited cleanly before the process exited.
+func TestShutdownHookRunsOnSIGTERM(t *testing.T) {
+	if os.Getenv("LAMBDA_TEST_HELPER_PROCESS") == "1" {
+		runShutdownHookHelperProcess()
+		os.Exit(0)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestShutdownHookRunsOnSIGTERM")
+	cmd.Env = append(os.Environ(), "LAMBDA_TEST_HELPER_PROCESS=1")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("failed to create stdout pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start helper process: %v", err)
+	}
+
+	// Give the helper a moment to install its signal handler and enter its
+	// long poll against the fake runtime API.
+	time.Sleep(100 * time.Millisecond)
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("failed to signal helper process: %v", err)
+	}
+
+	output := make([]byte, 0, 64)
+	buf := make([]byte, 64)
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	for {
+		n, readErr := stdout.Read(buf)
+		output = append(output, buf[:n]...)
+		if readErr != nil {
+			break
+		}
+	}
+	<-done
+
+	if got, want := string(output), "shutdown hook ran\nloop exited cleanly\n"; got != want {
+		t.Fatalf("unexpected helper process output: got %q, want %q", got, want)
+	}
+}
+
+// TestHandleOneInvocationRecoversHandlerPanic asserts that a panic inside the
+// handler is recovered, reported to the runtime API's error endpoint (rather
+// than crashing the process), and that handleOneInvocation returns a non-nil
+// error so the invoke loop stops instead of serving further invocations.
+func TestHandleOneInvocationRecoversHandlerPanic(t *testing.T) {
+	var errorBody []byte
+	var errorTypeHeader string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/invocation/next"):
+			w.Header().Set(headerAwsRequestID, "req-1")
+			w.Write([]byte(`{}`))
+		case strings.HasSuffix(r.URL.Path, "/error"):
+			errorTypeHeader = r.Header.Get(headerErrorType)
+			errorBody, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	handler := NewHandler(func() error { panic("boom") })
+	baseURL := server.URL
+
+	err := handleOneInvocation(context.Background(), server.Client(), baseURL, handler)
+	if err == nil {
+		t.Fatal("expected handleOneInvocation to return an error after a handler panic")
+	}
+
+	if errorBody == nil {
+		t.Fatal("expected the panic to be reported to the runtime API's error endpoint")
+	}
+
+	var reported messages.InvokeResponse_Error
+	if err := json.Unmarshal(errorBody, &reported); err != nil {
+		t.Fatalf("failed to decode reported error: %v", err)
+	}
+	if reported.Message != "boom" {
+		t.Errorf("unexpected reported message: got %q, want %q", reported.Message, "boom")
+	}
+	if errorTypeHeader == "" {
+		t.Error("expected the error type header to be set")
+	}
+}
+
+// runShutdownHookHelperProcess runs as the child in
+// TestShutdownHookRunsOnSIGTERM. It runs the real runtime invocation loop
+// (startRuntimeAPILoop) against an httptest server standing in for the
+// Lambda runtime API, whose /invocation/next handler never responds,
+// simulating the loop being blocked in a long poll when SIGTERM arrives.
+func runShutdownHookHelperProcess() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	hookRan := make(chan struct{})
+	opts := newOptions([]Option{
+		WithShutdownHook(func(ctx context.Context) {
+			os.Stdout.WriteString("shutdown hook ran\n")
+			close(hookRan)
+		}),
+		WithShutdownWindow(2 * time.Second),
+	})
+
+	handler := NewHandler(func() error { return nil })
+
+	loopErr := make(chan error, 1)
+	go func() {
+		loopErr <- startRuntimeAPILoop(server.Listener.Addr().String(), handler, opts)
+	}()
+
+	select {
+	case <-hookRan:
+	case <-time.After(5 * time.Second):
+		return
+	}
+
+	select {
+	case err := <-loopErr:
+		if err == nil {
+			os.Stdout.WriteString("loop exited cleanly\n")
+		}
+	case <-time.After(5 * time.Second):
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 Amazon.com, Inc. or its affiliates. All Rights Reserved
+
+// Package extension provides a client for the AWS Lambda Extensions API, so
+// that Go programs can register as an internal or external Lambda extension
+// and receive INVOKE and SHUTDOWN events from the Lambda runtime. See
+// https://docs.aws.amazon.com/lambda/latest/dg/runtimes-extensions-api.html
+// for the full protocol.
+package extension
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+const (
+	extensionNameHeader       = "Lambda-Extension-Name"
+	extensionIdentifierHeader = "Lambda-Extension-Identifier"
+	extensionErrorTypeHeader  = "Lambda-Extension-Function-Error-Type"
+
+	registerPath  = "/2020-01-01/extension/register"
+	nextEventPath = "/2020-01-01/extension/event/next"
+	initErrorPath = "/2020-01-01/extension/init/error"
+	exitErrorPath = "/2020-01-01/extension/exit/error"
+)
+
+// Client talks to the Lambda Extensions API on behalf of a single named
+// extension. A Client is not safe for concurrent use by multiple goroutines.
+type Client struct {
+	baseURL     string
+	name        string
+	httpClient  *http.Client
+	extensionID string
+}
+
+// NewClient creates a Client for the extension named name, talking to the
+// Extensions API at runtimeAPI (host:port, with no scheme). Callers normally
+// pass the AWS_LAMBDA_RUNTIME_API environment variable for runtimeAPI; see
+// NewClientFromEnvironment for a shortcut.
+func NewClient(name string, runtimeAPI string) *Client {
+	return &Client{
+		baseURL:    fmt.Sprintf("http://%s", runtimeAPI),
+		name:       name,
+		httpClient: &http.Client{},
+	}
+}
+
+// NewClientFromEnvironment creates a Client for the extension named name,
+// reading the Extensions API address from the AWS_LAMBDA_RUNTIME_API
+// environment variable.
+func NewClientFromEnvironment(name string) *Client {
+	return NewClient(name, os.Getenv("AWS_LAMBDA_RUNTIME_API"))
+}
+
+// EventType identifies the kind of event returned from Next.
+type EventType string
+
+const (
+	// Invoke is sent once per function invocation.
+	Invoke EventType = "INVOKE"
+	// Shutdown is sent once, immediately before the execution environment
+	// is torn down.
+	Shutdown EventType = "SHUTDOWN"
+)
+
+// RegisterResponse is the body returned from a successful Register call.
+type RegisterResponse struct {
+	FunctionName    string `json:"functionName"`
+	FunctionVersion string `json:"functionVersion"`
+	Handler         string `json:"handler"`
+}
+
+// NextEventResponse is the body returned from a successful Next call.
+type NextEventResponse struct {
+	EventType          EventType `json:"eventType"`
+	DeadlineMs         int64     `json:"deadlineMs"`
+	RequestID          string    `json:"requestId,omitempty"`
+	InvokedFunctionArn string    `json:"invokedFunctionArn,omitempty"`
+	ShutdownReason     string    `json:"shutdownReason,omitempty"`
+}
+
+// ErrorResponse describes an error reported via InitError or ExitError.
+type ErrorResponse struct {
+	ErrorMessage string   `json:"errorMessage"`
+	ErrorType    string   `json:"errorType,omitempty"`
+	StackTrace   []string `json:"stackTrace,omitempty"`
+}
+
+// Register announces this extension to the Lambda runtime and subscribes it
+// to eventTypes. It must be called once, before the first call to Next, and
+// persists the Lambda-Extension-Identifier used by every subsequent call.
+func (c *Client) Register(ctx context.Context, eventTypes []EventType) (*RegisterResponse, error) {
+	body, err := json.Marshal(struct {
+		Events []EventType `json:"events"`
+	}{Events: eventTypes})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+registerPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(extensionNameHeader, c.name)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("extension register failed with status %s: %s", resp.Status, readBody(resp.Body))
+	}
+
+	id := resp.Header.Get(extensionIdentifierHeader)
+	if id == "" {
+		return nil, fmt.Errorf("extension register response missing %s header", extensionIdentifierHeader)
+	}
+	c.extensionID = id
+
+	var out RegisterResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Next long-polls the Extensions API for the next INVOKE or SHUTDOWN event.
+// It blocks until an event is available, the context is cancelled, or the
+// execution environment is shut down.
+func (c *Client) Next(ctx context.Context) (*NextEventResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+nextEventPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(extensionIdentifierHeader, c.extensionID)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("extension next event failed with status %s: %s", resp.Status, readBody(resp.Body))
+	}
+
+	var out NextEventResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// InitError reports that the extension failed to initialize. The Lambda
+// runtime treats this as fatal and will not send any further events.
+func (c *Client) InitError(ctx context.Context, errorType string, errorResponse *ErrorResponse) error {
+	return c.postError(ctx, initErrorPath, errorType, errorResponse)
+}
+
+// ExitError reports that the extension is exiting due to an error, typically
+// from within a SHUTDOWN event handler.
+func (c *Client) ExitError(ctx context.Context, errorType string, errorResponse *ErrorResponse) error {
+	return c.postError(ctx, exitErrorPath, errorType, errorResponse)
+}
+
+func (c *Client) postError(ctx context.Context, path string, errorType string, errorResponse *ErrorResponse) error {
+	body, err := json.Marshal(errorResponse)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(extensionIdentifierHeader, c.extensionID)
+	req.Header.Set(extensionErrorTypeHeader, errorType)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("extension %s failed with status %s: %s", path, resp.Status, readBody(resp.Body))
+	}
+	return nil
+}
+
+func readBody(r io.Reader) string {
+	b, _ := io.ReadAll(r)
+	return string(b)
+}
@@ -0,0 +1,137 @@
+// Copyright 2023 Amazon.com, Inc. or its affiliates. All Rights Reserved
+
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegisterNextShutdown(t *testing.T) {
+	const extensionID = "test-extension-id"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(registerPath, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(extensionNameHeader); got != "my-extension" {
+			t.Errorf("unexpected %s header: %s", extensionNameHeader, got)
+		}
+		var body struct {
+			Events []EventType `json:"events"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode register body: %v", err)
+		}
+		if len(body.Events) != 2 || body.Events[0] != Invoke || body.Events[1] != Shutdown {
+			t.Errorf("unexpected subscribed events: %v", body.Events)
+		}
+		w.Header().Set(extensionIdentifierHeader, extensionID)
+		json.NewEncoder(w).Encode(RegisterResponse{
+			FunctionName:    "my-function",
+			FunctionVersion: "$LATEST",
+			Handler:         "main",
+		})
+	})
+
+	nextCalls := 0
+	mux.HandleFunc(nextEventPath, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(extensionIdentifierHeader); got != extensionID {
+			t.Errorf("unexpected %s header: %s", extensionIdentifierHeader, got)
+		}
+		nextCalls++
+		if nextCalls == 1 {
+			json.NewEncoder(w).Encode(NextEventResponse{
+				EventType: Invoke,
+				RequestID: "req-1",
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(NextEventResponse{
+			EventType:      Shutdown,
+			ShutdownReason: "spindown",
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient("my-extension", server.Listener.Addr().String())
+
+	reg, err := client.Register(context.Background(), []EventType{Invoke, Shutdown})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if reg.FunctionName != "my-function" {
+		t.Errorf("unexpected function name: %s", reg.FunctionName)
+	}
+
+	ev, err := client.Next(context.Background())
+	if err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	if ev.EventType != Invoke || ev.RequestID != "req-1" {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+
+	ev, err = client.Next(context.Background())
+	if err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	if ev.EventType != Shutdown || ev.ShutdownReason != "spindown" {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+}
+
+func TestInitAndExitError(t *testing.T) {
+	const extensionID = "test-extension-id"
+
+	var gotPath, gotErrorType string
+	var gotBody ErrorResponse
+
+	mux := http.NewServeMux()
+	handle := func(path string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			gotPath = path
+			gotErrorType = r.Header.Get(extensionErrorTypeHeader)
+			if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+				t.Fatalf("failed to decode error body: %v", err)
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+	mux.HandleFunc(initErrorPath, handle(initErrorPath))
+	mux.HandleFunc(exitErrorPath, handle(exitErrorPath))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := &Client{
+		baseURL:     "http://" + server.Listener.Addr().String(),
+		name:        "my-extension",
+		httpClient:  server.Client(),
+		extensionID: extensionID,
+	}
+
+	err := client.InitError(context.Background(), "Extension.InitError", &ErrorResponse{
+		ErrorMessage: "failed to connect",
+		ErrorType:    "Extension.InitError",
+	})
+	if err != nil {
+		t.Fatalf("InitError failed: %v", err)
+	}
+	if gotPath != initErrorPath || gotErrorType != "Extension.InitError" || gotBody.ErrorMessage != "failed to connect" {
+		t.Errorf("unexpected init error request: path=%s type=%s body=%+v", gotPath, gotErrorType, gotBody)
+	}
+
+	err = client.ExitError(context.Background(), "Extension.ExitError", &ErrorResponse{
+		ErrorMessage: "shutdown failed",
+	})
+	if err != nil {
+		t.Fatalf("ExitError failed: %v", err)
+	}
+	if gotPath != exitErrorPath || gotErrorType != "Extension.ExitError" || gotBody.ErrorMessage != "shutdown failed" {
+		t.Errorf("unexpected exit error request: path=%s type=%s body=%+v", gotPath, gotErrorType, gotBody)
+	}
+}
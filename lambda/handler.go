@@ -0,0 +1,161 @@
+// Copyright 2019 Amazon.com, Inc. or its affiliates. All Rights Reserved
+
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+)
+
+// Handler is the generic function type that the Lambda runtime invokes for
+// each event. Payload is the raw, unmarshalled JSON body of the event.
+type Handler interface {
+	Invoke(ctx context.Context, payload []byte) ([]byte, error)
+}
+
+// lambdaHandler is the adapter that NewHandler produces from a user-supplied
+// handler function of (almost) any shape.
+type lambdaHandler func(context.Context, []byte) (io.Reader, error)
+
+func (h lambdaHandler) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	response, err := h(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(response)
+}
+
+func errorHandler(e error) lambdaHandler {
+	return func(ctx context.Context, payload []byte) (io.Reader, error) {
+		return nil, e
+	}
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// NewHandler adapts handlerFunc into a Handler. handlerFunc must be a
+// function that accepts zero or one non-context arguments (with an optional
+// leading context.Context), and returns zero or one non-error values (with
+// an optional trailing error). For example, all of the following are valid:
+//
+//	func()
+//	func() error
+//	func(TIn) error
+//	func() (TOut, error)
+//	func(TIn) (TOut, error)
+//	func(context.Context) error
+//	func(context.Context, TIn) (TOut, error)
+//
+// If handlerFunc is already a Handler, it is returned unchanged. Any other
+// shape causes NewHandler to return a Handler whose Invoke always fails.
+func NewHandler(handlerFunc interface{}) Handler {
+	if handler, ok := handlerFunc.(Handler); ok {
+		return handler
+	}
+
+	handlerType := reflect.TypeOf(handlerFunc)
+	if handlerType == nil || handlerType.Kind() != reflect.Func {
+		return errorHandler(fmt.Errorf("handler is not a function, got %T", handlerFunc))
+	}
+
+	takesContext, err := handlerTakesContext(handlerType)
+	if err != nil {
+		return errorHandler(err)
+	}
+
+	if err := validateArguments(handlerType); err != nil {
+		return errorHandler(err)
+	}
+	if err := validateReturns(handlerType); err != nil {
+		return errorHandler(err)
+	}
+
+	handlerValue := reflect.ValueOf(handlerFunc)
+
+	return lambdaHandler(func(ctx context.Context, payload []byte) (io.Reader, error) {
+		args := make([]reflect.Value, 0, handlerType.NumIn())
+		if takesContext {
+			args = append(args, reflect.ValueOf(ctx))
+		}
+		if (handlerType.NumIn() == 1 && !takesContext) || handlerType.NumIn() == 2 {
+			eventType := handlerType.In(handlerType.NumIn() - 1)
+			event := reflect.New(eventType)
+			if err := json.Unmarshal(payload, event.Interface()); err != nil {
+				return nil, err
+			}
+			args = append(args, event.Elem())
+		}
+
+		results := handlerValue.Call(args)
+
+		var errResult error
+		if len(results) > 0 {
+			if errValue, ok := results[len(results)-1].Interface().(error); ok {
+				errResult = errValue
+			}
+		}
+		if errResult != nil {
+			return nil, errResult
+		}
+
+		var responseValue interface{}
+		if len(results) > 1 || (len(results) == 1 && handlerType.Out(0) != errorType) {
+			responseValue = results[0].Interface()
+		}
+		if responseValue == nil {
+			return bytes.NewReader(nil), nil
+		}
+
+		responseBytes, err := json.Marshal(responseValue)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(responseBytes), nil
+	})
+}
+
+func handlerTakesContext(handlerType reflect.Type) (bool, error) {
+	switch handlerType.NumIn() {
+	case 0:
+		return false, nil
+	case 1:
+		return handlerType.In(0) == contextType || handlerType.In(0).Implements(contextType), nil
+	case 2:
+		if handlerType.In(0) == contextType || handlerType.In(0).Implements(contextType) {
+			return true, nil
+		}
+		return false, fmt.Errorf("handler takes two arguments, but the first is not Context, got %s", handlerType.In(0))
+	}
+	return false, fmt.Errorf("handlers may not take more than two arguments, but handler takes %d", handlerType.NumIn())
+}
+
+func validateArguments(handlerType reflect.Type) error {
+	if handlerType.NumIn() > 2 {
+		return fmt.Errorf("handlers may not take more than two arguments, but handler takes %d", handlerType.NumIn())
+	}
+	return nil
+}
+
+func validateReturns(handlerType reflect.Type) error {
+	switch handlerType.NumOut() {
+	case 0, 1:
+		if handlerType.NumOut() == 1 && handlerType.Out(0) != errorType {
+			return nil
+		}
+		return nil
+	case 2:
+		if !handlerType.Out(1).Implements(errorType) {
+			return fmt.Errorf("handler returns two values, but the second does not implement error")
+		}
+		return nil
+	}
+	return fmt.Errorf("handlers may not return more than two values, but handler returns %d", handlerType.NumOut())
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 Amazon.com, Inc. or its affiliates. All Rights Reserved
+
+package lambda
+
+import (
+	"context"
+	"time"
+)
+
+// defaultShutdownWindow is how long StartWithOptions waits for shutdown
+// hooks to run after SIGTERM is received, unless overridden with
+// WithShutdownWindow.
+const defaultShutdownWindow = 500 * time.Millisecond
+
+// shutdownHook is invoked when the Lambda runtime delivers SIGTERM, which
+// happens when the execution environment is about to be torn down.
+type shutdownHook func(ctx context.Context)
+
+// Options holds the configuration assembled from the Option values passed
+// to StartWithOptions.
+type Options struct {
+	baseContext    context.Context
+	shutdownHooks  []shutdownHook
+	shutdownWindow time.Duration
+}
+
+// Option configures the behavior of StartWithOptions.
+type Option interface {
+	apply(*Options)
+}
+
+type optionFunc func(*Options)
+
+func (f optionFunc) apply(o *Options) {
+	f(o)
+}
+
+func newOptions(options []Option) *Options {
+	o := &Options{
+		baseContext:    context.Background(),
+		shutdownWindow: defaultShutdownWindow,
+	}
+	for _, option := range options {
+		option.apply(o)
+	}
+	return o
+}
+
+// WithContext sets the base context from which the context passed to the
+// handler for every invocation is derived. That derived context is cancelled
+// when the runtime delivers SIGTERM; ctx itself is not cancelled.
+func WithContext(ctx context.Context) Option {
+	return optionFunc(func(o *Options) {
+		o.baseContext = ctx
+	})
+}
+
+// WithShutdownHook registers a function to run when the runtime delivers
+// SIGTERM, before the process exits. Hooks run in the order they were
+// registered and share a single bounded shutdown window (see
+// WithShutdownWindow); a hook that blocks past the window may be cut off.
+func WithShutdownHook(hook func(ctx context.Context)) Option {
+	return optionFunc(func(o *Options) {
+		o.shutdownHooks = append(o.shutdownHooks, hook)
+	})
+}
+
+// WithShutdownWindow overrides the default window (500ms) given to shutdown
+// hooks to run after SIGTERM is received.
+func WithShutdownWindow(window time.Duration) Option {
+	return optionFunc(func(o *Options) {
+		o.shutdownWindow = window
+	})
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 Amazon.com, Inc. or its affiliates. All Rights Reserved
+
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const streamingResponseModeHeader = "Lambda-Runtime-Function-Response-Mode"
+
+// streamingResponseDelimiter separates the JSON prelude from the body bytes
+// in a streamed invocation response; the runtime looks for this exact
+// 8-byte sequence.
+var streamingResponseDelimiter = []byte{0, 0, 0, 0, 0, 0, 0, 0}
+
+// StreamingHandlerFunc is a Lambda Function URL handler that writes its
+// response body directly to w as it becomes available, instead of
+// buffering it in an events.LambdaFunctionURLResponse.Body string, and
+// that uses w to set the response's status code, headers (e.g.
+// Content-Type: text/event-stream for SSE), and cookies before the first
+// byte of the body is written. Use it with StartHandlerFunc to stream
+// large or slow responses (SSE, LLM tokens, large downloads) under
+// Lambda's RESPONSE_STREAM invoke mode.
+type StreamingHandlerFunc func(ctx context.Context, req events.LambdaFunctionURLRequest, w StreamingResponseWriter) error
+
+// StartHandlerFunc runs the Lambda runtime invocation loop for a streaming
+// Function URL handler, configured by options. Like StartWithOptions, it
+// does not return: on a fatal runtime error it logs the failure and exits
+// the process.
+func StartHandlerFunc(handler StreamingHandlerFunc, options ...Option) {
+	opts := newOptions(options)
+
+	api := os.Getenv("AWS_LAMBDA_RUNTIME_API")
+	if api == "" {
+		fmt.Fprintln(os.Stderr, "AWS_LAMBDA_RUNTIME_API is not set; are you running outside of Lambda?")
+		os.Exit(1)
+	}
+
+	if err := startStreamingRuntimeAPILoop(api, handler, opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func startStreamingRuntimeAPILoop(api string, handler StreamingHandlerFunc, opts *Options) error {
+	baseCtx, cancel := context.WithCancel(opts.baseContext)
+	defer cancel()
+	shutdown := installShutdownHandler(cancel, opts)
+
+	client := &http.Client{}
+	baseURL := fmt.Sprintf("http://%s/2018-06-01/runtime", api)
+
+	for {
+		if err := handleOneStreamingInvocation(baseCtx, client, baseURL, handler); err != nil {
+			return shutdownAwareError(shutdown, err)
+		}
+	}
+}
+
+func handleOneStreamingInvocation(baseCtx context.Context, client *http.Client, baseURL string, handler StreamingHandlerFunc) error {
+	invokeCtx, cancel, requestID, eventPayload, err := nextInvocation(baseCtx, client, baseURL)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	var req events.LambdaFunctionURLRequest
+	if err := json.Unmarshal(eventPayload, &req); err != nil {
+		return postInvocationError(client, baseURL, requestID, err)
+	}
+
+	invokeErr := postStreamingInvocationResponse(client, baseURL, requestID, func(w io.Writer) error {
+		rw := newStreamingResponseWriter(w)
+		handlerErr := handler(invokeCtx, req, rw)
+		if flushErr := rw.flushPrelude(); flushErr != nil {
+			// A handler that returns an error having never written a body
+			// (e.g. it failed before producing any output) still needs
+			// the prelude sent so the runtime gets a well-formed response.
+			if handlerErr == nil {
+				return flushErr
+			}
+		}
+		return handlerErr
+	})
+	if invokeErr != nil {
+		return postInvocationError(client, baseURL, requestID, invokeErr)
+	}
+	return nil
+}
+
+// postStreamingInvocationResponse POSTs a framed streaming response to the
+// runtime API, piping the bytes produce writes straight through to the HTTP
+// request body instead of buffering them.
+func postStreamingInvocationResponse(client *http.Client, baseURL string, requestID string, produce func(io.Writer) error) error {
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(produce(pipeWriter))
+	}()
+
+	url := fmt.Sprintf("%s/invocation/%s/response", baseURL, requestID)
+	req, err := http.NewRequest(http.MethodPost, url, pipeReader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", events.LambdaFunctionURLStreamingResponseContentType)
+	req.Header.Set(streamingResponseModeHeader, "streaming")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("runtime post streaming response failed with status %s: %s", resp.Status, body)
+	}
+	return nil
+}
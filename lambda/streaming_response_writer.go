@@ -0,0 +1,92 @@
+// Copyright 2023 Amazon.com, Inc. or its affiliates. All Rights Reserved
+
+package lambda
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// StreamingResponseWriter is passed to a StreamingHandlerFunc so it can set
+// the status code, headers, and cookies of a streamed Function URL
+// response before (or as) it writes the body. Header, SetCookies, and
+// SetStatusCode have no effect once the first byte of the body has been
+// written: the JSON prelude carrying them is flushed on that first Write.
+type StreamingResponseWriter interface {
+	io.Writer
+
+	// Header returns the headers that will be sent with the response. As
+	// with http.ResponseWriter, changes after the first call to Write are
+	// ignored.
+	Header() http.Header
+
+	// SetCookies sets the cookies that will be sent with the response. It
+	// has no effect once the first byte of the body has been written.
+	SetCookies(cookies []string)
+
+	// SetStatusCode sets the HTTP status code sent with the response,
+	// which defaults to http.StatusOK. It has no effect once the first
+	// byte of the body has been written.
+	SetStatusCode(statusCode int)
+}
+
+// streamingResponseWriter is the StreamingResponseWriter implementation
+// passed to every StreamingHandlerFunc invocation.
+type streamingResponseWriter struct {
+	w           io.Writer
+	header      http.Header
+	cookies     []string
+	statusCode  int
+	preludeSent bool
+}
+
+func newStreamingResponseWriter(w io.Writer) *streamingResponseWriter {
+	return &streamingResponseWriter{
+		w:          w,
+		header:     make(http.Header),
+		statusCode: http.StatusOK,
+	}
+}
+
+func (s *streamingResponseWriter) Header() http.Header {
+	return s.header
+}
+
+func (s *streamingResponseWriter) SetCookies(cookies []string) {
+	s.cookies = cookies
+}
+
+func (s *streamingResponseWriter) SetStatusCode(statusCode int) {
+	s.statusCode = statusCode
+}
+
+func (s *streamingResponseWriter) Write(p []byte) (int, error) {
+	if err := s.flushPrelude(); err != nil {
+		return 0, err
+	}
+	return s.w.Write(p)
+}
+
+// flushPrelude writes the JSON metadata prelude and its null-byte delimiter
+// the first time it is called; later calls are no-ops. It must run before
+// any body bytes are written, and must still run for handlers that write
+// no body at all.
+func (s *streamingResponseWriter) flushPrelude() error {
+	if s.preludeSent {
+		return nil
+	}
+	s.preludeSent = true
+
+	prelude, err := json.Marshal(events.NewLambdaFunctionURLStreamingResponse(s.statusCode, s.header, s.cookies))
+	if err != nil {
+		return err
+	}
+	if _, err := s.w.Write(prelude); err != nil {
+		return err
+	}
+	_, err = s.w.Write(streamingResponseDelimiter)
+	return err
+}
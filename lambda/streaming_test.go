@@ -0,0 +1,83 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved
+
+package lambda
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestStreamingResponseWriterPrelude(t *testing.T) {
+	var buf bytes.Buffer
+	w := newStreamingResponseWriter(&buf)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.SetCookies([]string{"session=abc"})
+	w.SetStatusCode(http.StatusCreated)
+
+	if _, err := w.Write([]byte("data: hello\n\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := w.Write([]byte("data: world\n\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	prelude, rest := splitOnDelimiter(t, buf.Bytes())
+
+	var got events.LambdaFunctionURLStreamingResponse
+	if err := json.Unmarshal(prelude, &got); err != nil {
+		t.Fatalf("failed to decode prelude: %v", err)
+	}
+	if got.StatusCode != http.StatusCreated {
+		t.Errorf("unexpected status code: %d", got.StatusCode)
+	}
+	if ct := http.Header(got.Headers).Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("unexpected Content-Type header: %q", ct)
+	}
+	if len(got.Cookies) != 1 || got.Cookies[0] != "session=abc" {
+		t.Errorf("unexpected cookies: %v", got.Cookies)
+	}
+
+	if string(rest) != "data: hello\n\ndata: world\n\n" {
+		t.Errorf("unexpected body bytes: %q", rest)
+	}
+}
+
+func TestStreamingResponseWriterFlushesPreludeWithNoBody(t *testing.T) {
+	var buf bytes.Buffer
+	w := newStreamingResponseWriter(&buf)
+	w.SetStatusCode(http.StatusNoContent)
+
+	if err := w.flushPrelude(); err != nil {
+		t.Fatalf("flushPrelude failed: %v", err)
+	}
+
+	prelude, rest := splitOnDelimiter(t, buf.Bytes())
+
+	var got events.LambdaFunctionURLStreamingResponse
+	if err := json.Unmarshal(prelude, &got); err != nil {
+		t.Fatalf("failed to decode prelude: %v", err)
+	}
+	if got.StatusCode != http.StatusNoContent {
+		t.Errorf("unexpected status code: %d", got.StatusCode)
+	}
+	if len(rest) != 0 {
+		t.Errorf("expected no body bytes, got %q", rest)
+	}
+}
+
+// splitOnDelimiter splits data on the 8 null-byte streamingResponseDelimiter
+// that the runtime API protocol requires between the JSON prelude and the
+// body, failing the test if the delimiter is missing.
+func splitOnDelimiter(t *testing.T, data []byte) (prelude, body []byte) {
+	t.Helper()
+	idx := bytes.Index(data, streamingResponseDelimiter)
+	if idx < 0 {
+		t.Fatalf("streaming response delimiter not found in %q", data)
+	}
+	return data[:idx], data[idx+len(streamingResponseDelimiter):]
+}